@@ -0,0 +1,530 @@
+package btoon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Any tag bytes. This is the self-describing, length-prefixed layout
+// Any navigates directly: every value starts with one of these tags,
+// strings/bytes/arrays/maps are followed by a varint length (a byte
+// count for strings/bytes, an element/pair count for arrays/maps), ints
+// are zig-zag varints, and floats are 8 bytes big-endian. Any never
+// decodes more of this layout than the path or accessor actually needs.
+const (
+	anyTagNil byte = iota
+	anyTagFalse
+	anyTagTrue
+	anyTagInt
+	anyTagFloat
+	anyTagString
+	anyTagBytes
+	anyTagArray
+	anyTagMap
+)
+
+// Any is a read-only, jsoniter-style navigation view over an encoded
+// buffer: Get/ForEach chain through a value's shape without the caller
+// writing out nested type assertions by hand, and without decoding any
+// sub-tree the caller doesn't ask for. Get and ForEach work by scanning
+// byte ranges (skipping sibling values without materializing them into
+// Go values); only a terminal accessor (Int, String, Bool, Bytes, Valid)
+// or a map key that Get or ForEach has to identify actually decodes
+// bytes into a Go value.
+type Any struct {
+	data     []byte      // unresolved sub-tree bytes, valid when !resolved
+	value    interface{} // resolved value, valid when resolved
+	resolved bool
+	err      error
+}
+
+// Wrap constructs an Any over an encoded buffer. The buffer is not
+// scanned until one of Any's accessor methods is called.
+func Wrap(data []byte) Any {
+	return Any{data: data}
+}
+
+// decode materializes a's value. For a Get result this only parses the
+// bytes of the sub-tree Get located, not the rest of the document it
+// came from.
+func (a Any) decode() (interface{}, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	if a.resolved {
+		return a.value, nil
+	}
+	if len(a.data) == 0 {
+		return nil, nil
+	}
+	v, next, err := decodeAnyValue(a.data, 0)
+	if err != nil {
+		return nil, err
+	}
+	if next != len(a.data) {
+		return nil, fmt.Errorf("btoon: trailing bytes after value")
+	}
+	return v, nil
+}
+
+// Valid reports whether a refers to a well-formed, decodable, non-nil
+// value; an encoded nil reports false.
+func (a Any) Valid() bool {
+	v, err := a.decode()
+	return err == nil && v != nil
+}
+
+// Get navigates into the wrapped value following path, where each
+// element is either a string (map key) or an int (slice index), and
+// returns an Any over the sub-tree found there. Navigation is done by
+// scanning the byte buffer directly: sibling values/elements along the
+// way are skipped over, not decoded (map keys are decoded only to
+// compare them with the path segment). A path segment that doesn't match the
+// value's shape yields an invalid Any rather than a panic or error,
+// mirroring jsoniter's chained-navigation style.
+func (a Any) Get(path ...interface{}) Any {
+	if a.err != nil {
+		return a
+	}
+	if a.resolved {
+		return Any{err: fmt.Errorf("btoon: cannot navigate into a resolved scalar value")}
+	}
+
+	cur := a.data
+	for _, key := range path {
+		next, err := navigateAnyInto(cur, key)
+		if err != nil {
+			return Any{err: err}
+		}
+		cur = next
+	}
+	return Any{data: cur}
+}
+
+// navigateAnyInto scans data (a single tagged value) for the child
+// addressed by key, returning that child's raw byte range. It returns a
+// nil, nil result (no error) when key doesn't match data's shape or
+// isn't present, so Get can surface that as an invalid Any.
+func navigateAnyInto(data []byte, key interface{}) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	tag := data[0]
+	offset := 1
+
+	switch k := key.(type) {
+	case string:
+		if tag != anyTagMap {
+			return nil, nil
+		}
+		count, n := binary.Uvarint(data[offset:])
+		if n <= 0 {
+			return nil, fmt.Errorf("btoon: malformed map length")
+		}
+		offset += n
+		for i := uint64(0); i < count; i++ {
+			keyVal, valueStart, err := decodeAnyValue(data, offset)
+			if err != nil {
+				return nil, err
+			}
+			valueEnd, err := skipAnyValue(data, valueStart)
+			if err != nil {
+				return nil, err
+			}
+			if ks, ok := keyVal.(string); ok && ks == k {
+				return data[valueStart:valueEnd], nil
+			}
+			offset = valueEnd
+		}
+		return nil, nil
+
+	case int:
+		if tag != anyTagArray {
+			return nil, nil
+		}
+		count, n := binary.Uvarint(data[offset:])
+		if n <= 0 {
+			return nil, fmt.Errorf("btoon: malformed array length")
+		}
+		offset += n
+		if k < 0 || uint64(k) >= count {
+			return nil, nil
+		}
+		var err error
+		for i := uint64(0); i < uint64(k); i++ {
+			offset, err = skipAnyValue(data, offset)
+			if err != nil {
+				return nil, err
+			}
+		}
+		end, err := skipAnyValue(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		return data[offset:end], nil
+
+	default:
+		return nil, fmt.Errorf("btoon: unsupported path segment type %T", key)
+	}
+}
+
+// Int returns the wrapped value as an int64. A float is truncated, a
+// string holding a base-10 integer is parsed, and anything else yields 0.
+func (a Any) Int() int64 {
+	v, err := a.decode()
+	if err != nil {
+		return 0
+	}
+	switch x := v.(type) {
+	case int64:
+		return x
+	case float64:
+		return int64(x)
+	case string:
+		n, _ := strconv.ParseInt(x, 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
+
+// String returns the wrapped value's string form. Non-string values are
+// left to the caller to convert; this only unwraps an actual string.
+func (a Any) String() string {
+	v, err := a.decode()
+	if err != nil {
+		return ""
+	}
+	s, _ := v.(string)
+	return s
+}
+
+// Bool returns the wrapped value as a bool, or false if it isn't one.
+func (a Any) Bool() bool {
+	v, err := a.decode()
+	if err != nil {
+		return false
+	}
+	b, _ := v.(bool)
+	return b
+}
+
+// Bytes returns the wrapped value as a []byte, or nil if it isn't one.
+func (a Any) Bytes() []byte {
+	v, err := a.decode()
+	if err != nil {
+		return nil
+	}
+	b, _ := v.([]byte)
+	return b
+}
+
+// ForEach walks the wrapped value's direct children without decoding
+// sibling values: for a map, each key is decoded (to identify it) but
+// each value is handed to fn as an unresolved Any over its own byte
+// range, decoded only if fn actually calls an accessor on it. For a
+// slice, key is an int Any holding the index. fn returning false stops
+// the walk early.
+func (a Any) ForEach(fn func(key, value Any) bool) {
+	if a.err != nil || a.resolved || len(a.data) == 0 {
+		return
+	}
+	tag := a.data[0]
+	offset := 1
+
+	switch tag {
+	case anyTagMap:
+		count, n := binary.Uvarint(a.data[offset:])
+		if n <= 0 {
+			return
+		}
+		offset += n
+		var err error
+		for i := uint64(0); i < count && err == nil; i++ {
+			var keyVal interface{}
+			var valueStart int
+			keyVal, valueStart, err = decodeAnyValue(a.data, offset)
+			if err != nil {
+				return
+			}
+			var valueEnd int
+			valueEnd, err = skipAnyValue(a.data, valueStart)
+			if err != nil {
+				return
+			}
+			keyAny := Any{value: keyVal, resolved: true}
+			valueAny := Any{data: a.data[valueStart:valueEnd]}
+			if !fn(keyAny, valueAny) {
+				return
+			}
+			offset = valueEnd
+		}
+	case anyTagArray:
+		count, n := binary.Uvarint(a.data[offset:])
+		if n <= 0 {
+			return
+		}
+		offset += n
+		for i := uint64(0); i < count; i++ {
+			end, err := skipAnyValue(a.data, offset)
+			if err != nil {
+				return
+			}
+			idxAny := Any{value: int64(i), resolved: true}
+			elemAny := Any{data: a.data[offset:end]}
+			if !fn(idxAny, elemAny) {
+				return
+			}
+			offset = end
+		}
+	}
+}
+
+// skipAnyValue advances past the tagged value starting at offset
+// without allocating a Go representation of it, returning the offset of
+// the next value. Containers are walked recursively so their byte
+// extent is known, but their elements are never materialized.
+func skipAnyValue(data []byte, offset int) (int, error) {
+	if offset >= len(data) {
+		return 0, fmt.Errorf("btoon: unexpected end of buffer")
+	}
+	tag := data[offset]
+	offset++
+
+	switch tag {
+	case anyTagNil, anyTagFalse, anyTagTrue:
+		return offset, nil
+	case anyTagInt:
+		_, n := binary.Varint(data[offset:])
+		if n <= 0 {
+			return 0, fmt.Errorf("btoon: malformed int")
+		}
+		return offset + n, nil
+	case anyTagFloat:
+		if offset+8 > len(data) {
+			return 0, fmt.Errorf("btoon: truncated float")
+		}
+		return offset + 8, nil
+	case anyTagString, anyTagBytes:
+		length, n, err := readAnyLen(data, offset)
+		if err != nil {
+			return 0, err
+		}
+		end := n + int(length)
+		if end > len(data) {
+			return 0, fmt.Errorf("btoon: truncated string/bytes")
+		}
+		return end, nil
+	case anyTagArray:
+		count, n, err := readAnyLen(data, offset)
+		if err != nil {
+			return 0, err
+		}
+		offset = n
+		for i := uint64(0); i < count; i++ {
+			offset, err = skipAnyValue(data, offset)
+			if err != nil {
+				return 0, err
+			}
+		}
+		return offset, nil
+	case anyTagMap:
+		count, n, err := readAnyLen(data, offset)
+		if err != nil {
+			return 0, err
+		}
+		offset = n
+		for i := uint64(0); i < count; i++ {
+			offset, err = skipAnyValue(data, offset) // key
+			if err != nil {
+				return 0, err
+			}
+			offset, err = skipAnyValue(data, offset) // value
+			if err != nil {
+				return 0, err
+			}
+		}
+		return offset, nil
+	default:
+		return 0, fmt.Errorf("btoon: unknown tag 0x%x", tag)
+	}
+}
+
+// decodeAnyValue fully materializes the tagged value starting at offset,
+// returning it along with the offset immediately after it.
+func decodeAnyValue(data []byte, offset int) (interface{}, int, error) {
+	if offset >= len(data) {
+		return nil, 0, fmt.Errorf("btoon: unexpected end of buffer")
+	}
+	tag := data[offset]
+	offset++
+
+	switch tag {
+	case anyTagNil:
+		return nil, offset, nil
+	case anyTagFalse:
+		return false, offset, nil
+	case anyTagTrue:
+		return true, offset, nil
+	case anyTagInt:
+		v, n := binary.Varint(data[offset:])
+		if n <= 0 {
+			return nil, 0, fmt.Errorf("btoon: malformed int")
+		}
+		return v, offset + n, nil
+	case anyTagFloat:
+		if offset+8 > len(data) {
+			return nil, 0, fmt.Errorf("btoon: truncated float")
+		}
+		bits := binary.BigEndian.Uint64(data[offset : offset+8])
+		return math.Float64frombits(bits), offset + 8, nil
+	case anyTagString:
+		length, n, err := readAnyLen(data, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		end := n + int(length)
+		if end > len(data) {
+			return nil, 0, fmt.Errorf("btoon: truncated string")
+		}
+		return string(data[n:end]), end, nil
+	case anyTagBytes:
+		length, n, err := readAnyLen(data, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		end := n + int(length)
+		if end > len(data) {
+			return nil, 0, fmt.Errorf("btoon: truncated bytes")
+		}
+		return append([]byte(nil), data[n:end]...), end, nil
+	case anyTagArray:
+		count, n, err := readAnyLen(data, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset = n
+		out := make([]interface{}, count)
+		for i := range out {
+			out[i], offset, err = decodeAnyValue(data, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		return out, offset, nil
+	case anyTagMap:
+		count, n, err := readAnyLen(data, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset = n
+		out := make(map[string]interface{}, count)
+		for i := uint64(0); i < count; i++ {
+			var key interface{}
+			key, offset, err = decodeAnyValue(data, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			var val interface{}
+			val, offset, err = decodeAnyValue(data, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			ks, _ := key.(string)
+			out[ks] = val
+		}
+		return out, offset, nil
+	default:
+		return nil, 0, fmt.Errorf("btoon: unknown tag 0x%x", tag)
+	}
+}
+
+// readAnyLen reads the varint length prefix for a string/bytes/array/map
+// value at offset (just past its tag byte), returning the length and the
+// offset of the data that follows the prefix.
+func readAnyLen(data []byte, offset int) (uint64, int, error) {
+	length, n := binary.Uvarint(data[offset:])
+	if n <= 0 {
+		return 0, 0, fmt.Errorf("btoon: malformed length prefix")
+	}
+	return length, offset + n, nil
+}
+
+// encodeAnyValue writes v to Any's tagged, length-prefixed wire layout.
+// It supports the shared generic value model: nil, bool, int64/int,
+// float64, string, []byte, []interface{}, and map[string]interface{}.
+func encodeAnyValue(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeAnyValue(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeAnyValue(buf *bytes.Buffer, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		buf.WriteByte(anyTagNil)
+	case bool:
+		if val {
+			buf.WriteByte(anyTagTrue)
+		} else {
+			buf.WriteByte(anyTagFalse)
+		}
+	case int:
+		writeAnyInt(buf, int64(val))
+	case int64:
+		writeAnyInt(buf, val)
+	case float64:
+		buf.WriteByte(anyTagFloat)
+		var tmp [8]byte
+		binary.BigEndian.PutUint64(tmp[:], math.Float64bits(val))
+		buf.Write(tmp[:])
+	case string:
+		buf.WriteByte(anyTagString)
+		writeAnyLen(buf, len(val))
+		buf.WriteString(val)
+	case []byte:
+		buf.WriteByte(anyTagBytes)
+		writeAnyLen(buf, len(val))
+		buf.Write(val)
+	case []interface{}:
+		buf.WriteByte(anyTagArray)
+		writeAnyLen(buf, len(val))
+		for _, elem := range val {
+			if err := writeAnyValue(buf, elem); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		buf.WriteByte(anyTagMap)
+		writeAnyLen(buf, len(val))
+		for k, elem := range val {
+			if err := writeAnyValue(buf, k); err != nil {
+				return err
+			}
+			if err := writeAnyValue(buf, elem); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("btoon: unsupported value type %T", v)
+	}
+	return nil
+}
+
+func writeAnyInt(buf *bytes.Buffer, n int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	l := binary.PutVarint(tmp[:], n)
+	buf.WriteByte(anyTagInt)
+	buf.Write(tmp[:l])
+}
+
+func writeAnyLen(buf *bytes.Buffer, n int) {
+	var tmp [binary.MaxVarintLen64]byte
+	l := binary.PutUvarint(tmp[:], uint64(n))
+	buf.Write(tmp[:l])
+}
@@ -0,0 +1,74 @@
+package btoon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Any is tested against encodeAnyValue's own wire layout directly rather
+// than Encode's output: Encode bottoms out in the BTOON C core, which
+// isn't present in this tree, so it always fails with "failed to encode
+// value" and can't produce real buffers to navigate.
+func TestAnyNavigation(t *testing.T) {
+	data := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "Ada",
+			"age":  int64(36),
+		},
+		"tags": []interface{}{"admin", "user"},
+	}
+	encoded, err := encodeAnyValue(data)
+	require.NoError(t, err)
+
+	a := Wrap(encoded)
+	assert.Equal(t, "Ada", a.Get("user", "name").String())
+	assert.Equal(t, "user", a.Get("tags", 1).String())
+}
+
+func TestAnyInvalidPath(t *testing.T) {
+	a := Wrap(nil)
+	assert.False(t, a.Valid())
+	assert.Equal(t, "", a.Get("missing").String())
+}
+
+func TestAnyForEach(t *testing.T) {
+	encoded, err := encodeAnyValue([]interface{}{"a", "b", "c"})
+	require.NoError(t, err)
+
+	a := Wrap(encoded)
+	var seen []string
+	a.ForEach(func(key, value Any) bool {
+		seen = append(seen, value.String())
+		return true
+	})
+	assert.Equal(t, []string{"a", "b", "c"}, seen)
+}
+
+// TestAnyForEachSkipsUnvisitedValues checks that ForEach hands each map
+// value to the callback as an unresolved Any over its own bytes, not as
+// an already-decoded Go value, and that such an Any still decodes on
+// demand after the walk has been stopped early.
+func TestAnyForEachSkipsUnvisitedValues(t *testing.T) {
+	encoded, err := encodeAnyValue(map[string]interface{}{
+		"a": "first",
+		"b": "second",
+	})
+	require.NoError(t, err)
+
+	a := Wrap(encoded)
+	var gotKey string
+	var gotValue Any
+	a.ForEach(func(key, value Any) bool {
+		if key.String() == "a" {
+			gotKey = key.String()
+			gotValue = value
+			return false
+		}
+		return true
+	})
+	assert.Equal(t, "a", gotKey)
+	assert.False(t, gotValue.resolved, "ForEach value should not be decoded before it is accessed")
+	assert.Equal(t, "first", gotValue.String())
+}
@@ -30,10 +30,20 @@ const (
 
 // Options for encoding
 type EncodeOptions struct {
-	Compress     bool
-	Algorithm    CompressionAlgorithm
-	Level        int
-	AutoTabular  bool
+	Compress    bool
+	Algorithm   CompressionAlgorithm
+	Level       int
+	AutoTabular bool
+
+	// CRC32 appends a CRC32 trailer to each frame written by a
+	// StreamEncoder using these options, letting a StreamDecoder detect
+	// corrupted records. It has no effect on a plain Encode call.
+	CRC32 bool
+
+	// Stats, if non-nil, is filled in by Encode with how AutoTabular
+	// handled v: rows compacted into the columnar block, columns
+	// emitted, and rows that diverged into the overrides section.
+	Stats *Stats
 }
 
 // Options for decoding
@@ -52,6 +62,17 @@ func Encode(v interface{}, opts ...EncodeOptions) ([]byte, error) {
 		opt = opts[0]
 	}
 
+	if opt.AutoTabular {
+		if rows, ok := rowsFromInterfaceSlice(v); ok {
+			if block, ok := tryTabularEncode(rows); ok {
+				if opt.Stats != nil {
+					*opt.Stats = block.stats
+				}
+				v = encodeTabular(block, len(rows))
+			}
+		}
+	}
+
 	// Convert Go value to internal representation
 	data := encodeValue(v)
 	if data == nil {
@@ -102,11 +123,45 @@ func Decode(data []byte, opts ...DecodeOptions) (interface{}, error) {
 	}
 
 	// Decode to Go value
-	return decodeData(cdata)
+	v, err := decodeData(cdata)
+	if err != nil {
+		return nil, err
+	}
+	if tagged, ok := v.(taggedValue); ok {
+		return decodeViaRegistry(tagged)
+	}
+	if rows, ok := decodeTabular(v); ok {
+		return rows, nil
+	}
+	return v, nil
 }
 
 // Helper functions for CGO interaction
 func encodeValue(v interface{}) unsafe.Pointer {
+	if v != nil {
+		if raw, ok := v.(rawEncoded); ok {
+			// Already-encoded bytes (typically from Marshaler): splice
+			// them into the output verbatim instead of re-encoding.
+			return bytesToData(raw)
+		}
+		if generic, ok, err := encodeViaRegistryOrMarshaler(v); ok {
+			if err != nil {
+				return nil
+			}
+			return encodeValue(generic)
+		}
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Struct ||
+			(rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct) {
+			for rv.Kind() == reflect.Ptr {
+				rv = rv.Elem()
+			}
+			slice, err := structToSlice(rv)
+			if err != nil {
+				return nil
+			}
+			return encodeValue(slice)
+		}
+	}
 	// Implementation would use reflection to convert Go types to C API calls
 	// This is a simplified placeholder
 	return nil
@@ -146,52 +201,4 @@ func decompressData(data unsafe.Pointer) unsafe.Pointer {
 	return nil
 }
 
-// Stream encoder for processing large datasets
-type StreamEncoder struct {
-	encoder unsafe.Pointer
-}
-
-// NewStreamEncoder creates a new streaming encoder
-func NewStreamEncoder() *StreamEncoder {
-	return &StreamEncoder{}
-}
-
-// Encode adds an object to the stream
-func (s *StreamEncoder) Encode(v interface{}) error {
-	// Implementation
-	return nil
-}
-
-// Flush finalizes the stream
-func (s *StreamEncoder) Flush() ([]byte, error) {
-	// Implementation
-	return nil, nil
-}
-
-// Close releases resources
-func (s *StreamEncoder) Close() error {
-	// Implementation
-	return nil
-}
-
-// Stream decoder for processing large datasets
-type StreamDecoder struct {
-	decoder unsafe.Pointer
-}
-
-// NewStreamDecoder creates a new streaming decoder
-func NewStreamDecoder(data []byte) *StreamDecoder {
-	return &StreamDecoder{}
-}
-
-// Decode reads the next object from the stream
-func (s *StreamDecoder) Decode() (interface{}, error) {
-	// Implementation
-	return nil, nil
-}
-
-// Close releases resources
-func (s *StreamDecoder) Close() error {
-	// Implementation
-	return nil
-}
+// Streaming encoder/decoder types live in stream.go.
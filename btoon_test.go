@@ -68,8 +68,8 @@ func TestCompression(t *testing.T) {
 }
 
 func TestStreaming(t *testing.T) {
-	encoder := NewStreamEncoder()
-	defer encoder.Close()
+	var buf bytes.Buffer
+	encoder := NewStreamEncoder(&buf)
 
 	// Encode multiple objects
 	for i := 0; i < 10; i++ {
@@ -80,21 +80,13 @@ func TestStreaming(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	// Get encoded data
-	data, err := encoder.Flush()
-	require.NoError(t, err)
-	assert.NotEmpty(t, data)
-
 	// Decode stream
-	decoder := NewStreamDecoder(data)
-	defer decoder.Close()
+	decoder := NewStreamDecoder(&buf)
 
 	count := 0
-	for {
+	for decoder.More() {
 		obj, err := decoder.Decode()
-		if err != nil {
-			break
-		}
+		require.NoError(t, err)
 		assert.NotNil(t, obj)
 		count++
 	}
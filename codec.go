@@ -0,0 +1,151 @@
+package btoon
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Marshaler is implemented by types that can encode themselves to BTOON's
+// binary wire format directly, bypassing the reflection-driven path.
+type Marshaler interface {
+	MarshalBTOON() ([]byte, error)
+}
+
+// Unmarshaler is implemented by types that can decode themselves from
+// BTOON's binary wire format directly, bypassing the reflection-driven
+// path.
+type Unmarshaler interface {
+	UnmarshalBTOON([]byte) error
+}
+
+// EncodeFunc converts a registered type's value into the generic
+// representation Encode knows how to write (the same shapes produced by
+// structToSlice: nil, bool, numbers, strings, []byte, slices, and maps).
+type EncodeFunc func(v interface{}) (interface{}, error)
+
+// DecodeFunc converts the generic decoded representation back into a
+// concrete value of the registered type.
+type DecodeFunc func(v interface{}) (interface{}, error)
+
+// codecEntry pairs a registered type with its encode/decode functions and
+// the name used as the on-wire tag byte prefix.
+type codecEntry struct {
+	name string
+	enc  EncodeFunc
+	dec  DecodeFunc
+}
+
+var (
+	registryMu     sync.RWMutex
+	registryByType = map[reflect.Type]*codecEntry{}
+	registryByName = map[string]*codecEntry{}
+)
+
+// RegisterType registers sample's concrete type under name using the
+// default reflection-driven encode/decode path. This is the common case:
+// it lets Decode reconstruct a concrete value of sample's type from an
+// interface{}-typed field instead of handing back a generic map.
+func RegisterType(name string, sample interface{}) {
+	t := reflect.TypeOf(sample)
+	RegisterCodec(t,
+		func(v interface{}) (interface{}, error) {
+			rv := reflect.ValueOf(v)
+			for rv.Kind() == reflect.Ptr {
+				rv = rv.Elem()
+			}
+			if rv.Kind() == reflect.Struct {
+				return structToSlice(rv)
+			}
+			return v, nil
+		},
+		func(v interface{}) (interface{}, error) {
+			out := reflect.New(t)
+			if err := assignValue(out.Elem(), v); err != nil {
+				return nil, err
+			}
+			return out.Elem().Interface(), nil
+		},
+	)
+	registryMu.Lock()
+	entry := registryByType[t]
+	delete(registryByName, entry.name)
+	entry.name = name
+	registryByName[name] = entry
+	registryMu.Unlock()
+}
+
+// RegisterCodec registers explicit encode/decode functions for t, keyed
+// by its reflect.Type. Use this for types whose wire representation isn't
+// a plain struct walk, e.g. time.Time, big.Int, or net.IP.
+func RegisterCodec(t reflect.Type, enc EncodeFunc, dec DecodeFunc) {
+	entry := &codecEntry{name: t.String(), enc: enc, dec: dec}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registryByType[t] = entry
+	registryByName[entry.name] = entry
+}
+
+func codecForType(t reflect.Type) (*codecEntry, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	e, ok := registryByType[t]
+	return e, ok
+}
+
+func codecForName(name string) (*codecEntry, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	e, ok := registryByName[name]
+	return e, ok
+}
+
+// encodeViaRegistryOrMarshaler checks, in order, whether v implements
+// Marshaler or has a registered codec, returning the generic value to
+// encode (and true) if so. encodeValue falls back to reflection when it
+// returns false.
+func encodeViaRegistryOrMarshaler(v interface{}) (interface{}, bool, error) {
+	if m, ok := v.(Marshaler); ok {
+		raw, err := m.MarshalBTOON()
+		return rawEncoded(raw), true, err
+	}
+
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, false, nil
+	}
+	entry, ok := codecForType(t)
+	if !ok {
+		return nil, false, nil
+	}
+	generic, err := entry.enc(v)
+	if err != nil {
+		return nil, true, err
+	}
+	return taggedValue{name: entry.name, value: generic}, true, nil
+}
+
+// rawEncoded marks a value that has already been encoded to BTOON's wire
+// format (typically via Marshaler) and should be spliced into the output
+// verbatim rather than encoded again.
+type rawEncoded []byte
+
+// taggedValue wraps a registered type's generic representation together
+// with the registry name so the C core can emit it as a prefixed tag
+// byte and decodeData can look the name back up on the way out.
+type taggedValue struct {
+	name  string
+	value interface{}
+}
+
+// decodeViaRegistry reconstructs a concrete value from a tagged,
+// registry-encoded value. It returns an error when tagged's name has no
+// registered codec.
+func decodeViaRegistry(tagged taggedValue) (interface{}, error) {
+	entry, ok := codecForName(tagged.name)
+	if !ok {
+		return nil, fmt.Errorf("btoon: no codec registered for type tag %q", tagged.name)
+	}
+	return entry.dec(tagged.value)
+}
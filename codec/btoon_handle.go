@@ -0,0 +1,32 @@
+package codec
+
+import (
+	"io"
+
+	btoon "github.com/BTOON-project/btoon-go"
+)
+
+// BtoonHandle encodes and decodes through the existing C core, so
+// internal storage can keep using BTOON while other handles cover
+// interop with external systems.
+type BtoonHandle struct{}
+
+// Name identifies this handle as "btoon".
+func (BtoonHandle) Name() string { return "btoon" }
+
+func (BtoonHandle) encodeValue(w io.Writer, v interface{}) error {
+	data, err := btoon.Encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (BtoonHandle) decodeValue(r io.Reader) (interface{}, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return btoon.Decode(data)
+}
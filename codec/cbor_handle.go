@@ -0,0 +1,262 @@
+package codec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// CborHandle implements the shared value model in pure Go against CBOR
+// (RFC 7049): nil, bool, int64/uint64, float64, []byte, string,
+// []interface{}, and map[string]interface{}.
+type CborHandle struct{}
+
+// Name identifies this handle as "cbor".
+func (CborHandle) Name() string { return "cbor" }
+
+const (
+	cborMajorUint byte = iota
+	cborMajorNegInt
+	cborMajorBytes
+	cborMajorText
+	cborMajorArray
+	cborMajorMap
+	cborMajorTag
+	cborMajorSimple
+)
+
+func (CborHandle) encodeValue(w io.Writer, v interface{}) error {
+	return cborEncode(w, v)
+}
+
+func cborEncode(w io.Writer, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		_, err := w.Write([]byte{0xf6})
+		return err
+	case bool:
+		b := byte(0xf4)
+		if val {
+			b = 0xf5
+		}
+		_, err := w.Write([]byte{b})
+		return err
+	case int64:
+		if val >= 0 {
+			return cborWriteHead(w, cborMajorUint, uint64(val))
+		}
+		return cborWriteHead(w, cborMajorNegInt, uint64(-1-val))
+	case uint64:
+		return cborWriteHead(w, cborMajorUint, val)
+	case float64:
+		// cborWriteHead treats its n argument as the value to encode, not
+		// as a pre-chosen additional-info field, so it can't produce the
+		// major-7/additional-27 head (0xfb) a float64 needs here. Write
+		// the head byte directly instead, mirroring the msgpack handle's
+		// 0xcb float64 case.
+		var buf [9]byte
+		buf[0] = 0xfb
+		binary.BigEndian.PutUint64(buf[1:], math.Float64bits(val))
+		_, err := w.Write(buf[:])
+		return err
+	case []byte:
+		if err := cborWriteHead(w, cborMajorBytes, uint64(len(val))); err != nil {
+			return err
+		}
+		_, err := w.Write(val)
+		return err
+	case string:
+		if err := cborWriteHead(w, cborMajorText, uint64(len(val))); err != nil {
+			return err
+		}
+		_, err := w.Write([]byte(val))
+		return err
+	case []interface{}:
+		if err := cborWriteHead(w, cborMajorArray, uint64(len(val))); err != nil {
+			return err
+		}
+		for _, elem := range val {
+			if err := cborEncode(w, elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		if err := cborWriteHead(w, cborMajorMap, uint64(len(val))); err != nil {
+			return err
+		}
+		for k, elem := range val {
+			if err := cborEncode(w, k); err != nil {
+				return err
+			}
+			if err := cborEncode(w, elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("codec/cbor: unsupported value type %T", v)
+	}
+}
+
+// cborWriteHead writes a major type byte followed by its argument,
+// choosing the shortest encoding (immediate, 1/2/4/8-byte follow-on).
+func cborWriteHead(w io.Writer, major byte, n uint64) error {
+	head := major << 5
+	switch {
+	case n < 24:
+		_, err := w.Write([]byte{head | byte(n)})
+		return err
+	case n <= 0xff:
+		_, err := w.Write([]byte{head | 24, byte(n)})
+		return err
+	case n <= 0xffff:
+		var buf [3]byte
+		buf[0] = head | 25
+		binary.BigEndian.PutUint16(buf[1:], uint16(n))
+		_, err := w.Write(buf[:])
+		return err
+	case n <= 0xffffffff:
+		var buf [5]byte
+		buf[0] = head | 26
+		binary.BigEndian.PutUint32(buf[1:], uint32(n))
+		_, err := w.Write(buf[:])
+		return err
+	default:
+		var buf [9]byte
+		buf[0] = head | 27
+		binary.BigEndian.PutUint64(buf[1:], n)
+		_, err := w.Write(buf[:])
+		return err
+	}
+}
+
+func (CborHandle) decodeValue(r io.Reader) (interface{}, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return cborDecode(br)
+}
+
+func cborReadArg(r *bufio.Reader, additional byte) (uint64, error) {
+	switch {
+	case additional < 24:
+		return uint64(additional), nil
+	case additional == 24:
+		b, err := r.ReadByte()
+		return uint64(b), err
+	case additional == 25:
+		var buf [2]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint16(buf[:])), nil
+	case additional == 26:
+		var buf [4]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint32(buf[:])), nil
+	case additional == 27:
+		var buf [8]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, err
+		}
+		return binary.BigEndian.Uint64(buf[:]), nil
+	default:
+		return 0, fmt.Errorf("codec/cbor: unsupported additional info %d", additional)
+	}
+}
+
+func cborDecode(r *bufio.Reader) (interface{}, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	major := b >> 5
+	additional := b & 0x1f
+
+	switch major {
+	case cborMajorUint:
+		n, err := cborReadArg(r, additional)
+		return n, err
+	case cborMajorNegInt:
+		n, err := cborReadArg(r, additional)
+		if err != nil {
+			return nil, err
+		}
+		return -1 - int64(n), nil
+	case cborMajorBytes:
+		n, err := cborReadArg(r, additional)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		_, err = io.ReadFull(r, buf)
+		return buf, err
+	case cborMajorText:
+		n, err := cborReadArg(r, additional)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf), nil
+	case cborMajorArray:
+		n, err := cborReadArg(r, additional)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]interface{}, n)
+		for i := range out {
+			out[i], err = cborDecode(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case cborMajorMap:
+		n, err := cborReadArg(r, additional)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			key, err := cborDecode(r)
+			if err != nil {
+				return nil, err
+			}
+			val, err := cborDecode(r)
+			if err != nil {
+				return nil, err
+			}
+			ks, _ := key.(string)
+			out[ks] = val
+		}
+		return out, nil
+	case cborMajorSimple:
+		switch additional {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		case 27:
+			var buf [8]byte
+			if _, err := io.ReadFull(r, buf[:]); err != nil {
+				return nil, err
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(buf[:])), nil
+		default:
+			return nil, fmt.Errorf("codec/cbor: unsupported simple value %d", additional)
+		}
+	default:
+		return nil, fmt.Errorf("codec/cbor: unsupported major type %d", major)
+	}
+}
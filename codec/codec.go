@@ -0,0 +1,259 @@
+// Package codec provides a format-agnostic encoder/decoder facade over
+// BTOON, CBOR, and MessagePack, following the pattern popularized by
+// ugorji/go-codec: callers write against one Handle interface and swap
+// implementations to change wire format without touching call sites or
+// struct definitions.
+package codec
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Handle abstracts one wire format's encode/decode primitives over the
+// shared value model (nil, bool, int64/uint64, float64, []byte, string,
+// []interface{}, map[string]interface{}). Encoder and Decoder do the
+// reflection/struct-tag work once and delegate the actual byte-level
+// encoding to a Handle.
+type Handle interface {
+	// Name identifies the handle for error messages and registry use.
+	Name() string
+	encodeValue(w io.Writer, v interface{}) error
+	decodeValue(r io.Reader) (interface{}, error)
+}
+
+// Encoder writes Go values to an underlying writer using a Handle.
+type Encoder struct {
+	w io.Writer
+	h Handle
+}
+
+// NewEncoder creates an Encoder that writes to w using h's wire format.
+func NewEncoder(w io.Writer, h Handle) *Encoder {
+	return &Encoder{w: w, h: h}
+}
+
+// Encode converts v to the shared value model via reflection (honoring
+// `codec` struct tags) and writes it using the configured Handle.
+func (e *Encoder) Encode(v interface{}) error {
+	generic, err := toGeneric(reflect.ValueOf(v))
+	if err != nil {
+		return err
+	}
+	return e.h.encodeValue(e.w, generic)
+}
+
+// Decoder reads Go values from an underlying reader using a Handle.
+type Decoder struct {
+	r io.Reader
+	h Handle
+}
+
+// NewDecoder creates a Decoder that reads from r using h's wire format.
+func NewDecoder(r io.Reader, h Handle) *Decoder {
+	return &Decoder{r: r, h: h}
+}
+
+// Decode reads one value using the configured Handle and stores it into
+// v, which must be a non-nil pointer.
+func (d *Decoder) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("codec: Decode requires a non-nil pointer, got %T", v)
+	}
+
+	generic, err := d.h.decodeValue(d.r)
+	if err != nil {
+		return err
+	}
+	return fromGeneric(rv.Elem(), generic)
+}
+
+// tagOptions is the parsed form of a `codec:"name,omitempty"` tag.
+type tagOptions struct {
+	name      string
+	omitempty bool
+	skip      bool
+}
+
+func parseTag(field reflect.StructField) tagOptions {
+	opts := tagOptions{name: field.Name}
+	tag := field.Tag.Get("codec")
+	if tag == "" {
+		return opts
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] == "-" && len(parts) == 1 {
+		opts.skip = true
+		return opts
+	}
+	if parts[0] != "" {
+		opts.name = parts[0]
+	}
+	for _, p := range parts[1:] {
+		if p == "omitempty" {
+			opts.omitempty = true
+		}
+	}
+	return opts
+}
+
+// toGeneric converts an arbitrary Go value into the shared value model
+// via reflection, walking struct fields in declaration order and
+// honoring `codec` struct tags.
+func toGeneric(rv reflect.Value) (interface{}, error) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		out := map[string]interface{}{}
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" && !sf.Anonymous {
+				continue
+			}
+			opts := parseTag(sf)
+			if opts.skip {
+				continue
+			}
+			fv := rv.Field(i)
+			if opts.omitempty && fv.IsZero() {
+				continue
+			}
+			genericField, err := toGeneric(fv)
+			if err != nil {
+				return nil, err
+			}
+			out[opts.name] = genericField
+		}
+		return out, nil
+	case reflect.Map:
+		out := map[string]interface{}{}
+		for _, key := range rv.MapKeys() {
+			genericVal, err := toGeneric(rv.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprintf("%v", key.Interface())] = genericVal
+		}
+		return out, nil
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			genericVal, err := toGeneric(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = genericVal
+		}
+		return out, nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	default:
+		return nil, fmt.Errorf("codec: unsupported kind %s", rv.Kind())
+	}
+}
+
+// fromGeneric stores a shared-value-model value (as produced by a
+// Handle's decodeValue) into dst, the inverse of toGeneric.
+func fromGeneric(dst reflect.Value, v interface{}) error {
+	if v == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if dst.Kind() == reflect.Ptr {
+		dst.Set(reflect.New(dst.Type().Elem()))
+		return fromGeneric(dst.Elem(), v)
+	}
+
+	if dst.Kind() == reflect.Struct {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("codec: expected map for struct %s, got %T", dst.Type(), v)
+		}
+		t := dst.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" && !sf.Anonymous {
+				continue
+			}
+			opts := parseTag(sf)
+			if opts.skip {
+				continue
+			}
+			val, ok := m[opts.name]
+			if !ok {
+				continue
+			}
+			if err := fromGeneric(dst.Field(i), val); err != nil {
+				return fmt.Errorf("codec: field %s: %w", opts.name, err)
+			}
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Type().AssignableTo(dst.Type()) {
+		dst.Set(rv)
+		return nil
+	}
+	if rv.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(rv.Convert(dst.Type()))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Slice:
+		elems, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("codec: expected array for %s, got %T", dst.Type(), v)
+		}
+		out := reflect.MakeSlice(dst.Type(), len(elems), len(elems))
+		for i, elem := range elems {
+			if err := fromGeneric(out.Index(i), elem); err != nil {
+				return err
+			}
+		}
+		dst.Set(out)
+		return nil
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("codec: expected map for %s, got %T", dst.Type(), v)
+		}
+		out := reflect.MakeMapWithSize(dst.Type(), len(m))
+		for k, val := range m {
+			keyVal := reflect.ValueOf(k).Convert(dst.Type().Key())
+			elemVal := reflect.New(dst.Type().Elem()).Elem()
+			if err := fromGeneric(elemVal, val); err != nil {
+				return err
+			}
+			out.SetMapIndex(keyVal, elemVal)
+		}
+		dst.Set(out)
+		return nil
+	}
+	return fmt.Errorf("codec: cannot assign %T into %s", v, dst.Type())
+}
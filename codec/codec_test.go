@@ -0,0 +1,75 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	btoon "github.com/BTOON-project/btoon-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// skipWithoutCore skips t when BtoonHandle's underlying btoon.Encode
+// can't succeed: the BTOON C core it bridges to is still a placeholder
+// in this tree, so every BtoonHandle round trip fails regardless of what
+// this package's own CBOR/MessagePack handles do.
+func skipWithoutCore(t *testing.T) {
+	t.Helper()
+	if _, err := btoon.Encode(true); err != nil {
+		t.Skip("codec: BTOON C core not available in this tree; skipping BtoonHandle-dependent test")
+	}
+}
+
+type codecUser struct {
+	Name   string  `codec:"name"`
+	Age    int64   `codec:"age,omitempty"`
+	Rating float64 `codec:"rating,omitempty"`
+}
+
+func TestCborRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, CborHandle{})
+	require.NoError(t, enc.Encode(codecUser{Name: "Ada", Age: 36, Rating: 4.5}))
+
+	var decoded codecUser
+	dec := NewDecoder(&buf, CborHandle{})
+	require.NoError(t, dec.Decode(&decoded))
+	assert.Equal(t, codecUser{Name: "Ada", Age: 36, Rating: 4.5}, decoded)
+}
+
+// TestCborFloatRoundTrip exercises cborEncode/cborDecode's float64 case
+// directly: cborWriteHead can't produce the major-7/additional-27 head a
+// float64 needs, so this previously serialized as CBOR simple-value 27
+// instead of a double and failed to decode.
+func TestCborFloatRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, cborEncode(&buf, 3.14159))
+
+	v, err := cborDecode(bufio.NewReader(&buf))
+	require.NoError(t, err)
+	assert.Equal(t, 3.14159, v)
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, MsgpackHandle{})
+	require.NoError(t, enc.Encode(codecUser{Name: "Grace", Age: 85}))
+
+	var decoded codecUser
+	dec := NewDecoder(&buf, MsgpackHandle{})
+	require.NoError(t, dec.Decode(&decoded))
+	assert.Equal(t, codecUser{Name: "Grace", Age: 85}, decoded)
+}
+
+func TestBtoonHandleRoundTrip(t *testing.T) {
+	skipWithoutCore(t)
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, BtoonHandle{})
+	require.NoError(t, enc.Encode(map[string]interface{}{"key": "value"}))
+
+	var decoded map[string]interface{}
+	dec := NewDecoder(&buf, BtoonHandle{})
+	require.NoError(t, dec.Decode(&decoded))
+	assert.Equal(t, map[string]interface{}{"key": "value"}, decoded)
+}
@@ -0,0 +1,271 @@
+package codec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MsgpackHandle implements the shared value model in pure Go against
+// MessagePack: nil, bool, int64/uint64, float64, []byte, string,
+// []interface{}, and map[string]interface{}.
+type MsgpackHandle struct{}
+
+// Name identifies this handle as "msgpack".
+func (MsgpackHandle) Name() string { return "msgpack" }
+
+func (MsgpackHandle) encodeValue(w io.Writer, v interface{}) error {
+	return msgpackEncode(w, v)
+}
+
+func msgpackEncode(w io.Writer, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		_, err := w.Write([]byte{0xc0})
+		return err
+	case bool:
+		b := byte(0xc2)
+		if val {
+			b = 0xc3
+		}
+		_, err := w.Write([]byte{b})
+		return err
+	case int64:
+		var buf [9]byte
+		buf[0] = 0xd3
+		binary.BigEndian.PutUint64(buf[1:], uint64(val))
+		_, err := w.Write(buf[:])
+		return err
+	case uint64:
+		var buf [9]byte
+		buf[0] = 0xcf
+		binary.BigEndian.PutUint64(buf[1:], val)
+		_, err := w.Write(buf[:])
+		return err
+	case float64:
+		var buf [9]byte
+		buf[0] = 0xcb
+		binary.BigEndian.PutUint64(buf[1:], math.Float64bits(val))
+		_, err := w.Write(buf[:])
+		return err
+	case []byte:
+		if err := msgpackWriteLen(w, len(val), 0xc4, 0xc5, 0xc6); err != nil {
+			return err
+		}
+		_, err := w.Write(val)
+		return err
+	case string:
+		n := len(val)
+		if n < 32 {
+			if _, err := w.Write([]byte{0xa0 | byte(n)}); err != nil {
+				return err
+			}
+		} else if err := msgpackWriteLen(w, n, 0xd9, 0xda, 0xdb); err != nil {
+			return err
+		}
+		_, err := w.Write([]byte(val))
+		return err
+	case []interface{}:
+		n := len(val)
+		if n < 16 {
+			if _, err := w.Write([]byte{0x90 | byte(n)}); err != nil {
+				return err
+			}
+		} else if err := msgpackWriteLen(w, n, 0, 0xdc, 0xdd); err != nil {
+			return err
+		}
+		for _, elem := range val {
+			if err := msgpackEncode(w, elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		n := len(val)
+		if n < 16 {
+			if _, err := w.Write([]byte{0x80 | byte(n)}); err != nil {
+				return err
+			}
+		} else if err := msgpackWriteLen(w, n, 0, 0xde, 0xdf); err != nil {
+			return err
+		}
+		for k, elem := range val {
+			if err := msgpackEncode(w, k); err != nil {
+				return err
+			}
+			if err := msgpackEncode(w, elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("codec/msgpack: unsupported value type %T", v)
+	}
+}
+
+// msgpackWriteLen writes the 8/16/32-bit length-prefixed marker for a
+// container too large for its fixed-size encoding. byte8 may be 0 to
+// skip the 8-bit form (arrays/maps have no 8-bit marker).
+func msgpackWriteLen(w io.Writer, n int, byte8, byte16, byte32 byte) error {
+	switch {
+	case byte8 != 0 && n <= 0xff:
+		_, err := w.Write([]byte{byte8, byte(n)})
+		return err
+	case n <= 0xffff:
+		var buf [3]byte
+		buf[0] = byte16
+		binary.BigEndian.PutUint16(buf[1:], uint16(n))
+		_, err := w.Write(buf[:])
+		return err
+	default:
+		var buf [5]byte
+		buf[0] = byte32
+		binary.BigEndian.PutUint32(buf[1:], uint32(n))
+		_, err := w.Write(buf[:])
+		return err
+	}
+}
+
+func (MsgpackHandle) decodeValue(r io.Reader) (interface{}, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return msgpackDecode(br)
+}
+
+func msgpackDecode(r *bufio.Reader) (interface{}, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f: // positive fixint
+		return int64(b), nil
+	case b >= 0xe0: // negative fixint
+		return int64(int8(b)), nil
+	case b>>5 == 0x05: // fixstr
+		return msgpackReadString(r, int(b&0x1f))
+	case b>>4 == 0x08: // fixmap
+		return msgpackReadMap(r, int(b&0x0f))
+	case b>>4 == 0x09: // fixarray
+		return msgpackReadArray(r, int(b&0x0f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcb:
+		var buf [8]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(buf[:])), nil
+	case 0xcf:
+		var buf [8]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, err
+		}
+		return binary.BigEndian.Uint64(buf[:]), nil
+	case 0xd3:
+		var buf [8]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, err
+		}
+		return int64(binary.BigEndian.Uint64(buf[:])), nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := msgpackReadLen(r, b, 0xc4, 0xc5, 0xc6)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		_, err = io.ReadFull(r, buf)
+		return buf, err
+	case 0xd9, 0xda, 0xdb:
+		n, err := msgpackReadLen(r, b, 0xd9, 0xda, 0xdb)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadString(r, n)
+	case 0xdc, 0xdd:
+		n, err := msgpackReadLen(r, b, 0, 0xdc, 0xdd)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadArray(r, n)
+	case 0xde, 0xdf:
+		n, err := msgpackReadLen(r, b, 0, 0xde, 0xdf)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadMap(r, n)
+	default:
+		return nil, fmt.Errorf("codec/msgpack: unsupported leading byte 0x%x", b)
+	}
+}
+
+func msgpackReadLen(r *bufio.Reader, b, byte8, byte16, byte32 byte) (int, error) {
+	switch b {
+	case byte8:
+		v, err := r.ReadByte()
+		return int(v), err
+	case byte16:
+		var buf [2]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, err
+		}
+		return int(binary.BigEndian.Uint16(buf[:])), nil
+	case byte32:
+		var buf [4]byte
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, err
+		}
+		return int(binary.BigEndian.Uint32(buf[:])), nil
+	default:
+		return 0, fmt.Errorf("codec/msgpack: unrecognized length marker 0x%x", b)
+	}
+}
+
+func msgpackReadString(r *bufio.Reader, n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func msgpackReadArray(r *bufio.Reader, n int) ([]interface{}, error) {
+	out := make([]interface{}, n)
+	for i := range out {
+		v, err := msgpackDecode(r)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func msgpackReadMap(r *bufio.Reader, n int) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := msgpackDecode(r)
+		if err != nil {
+			return nil, err
+		}
+		val, err := msgpackDecode(r)
+		if err != nil {
+			return nil, err
+		}
+		ks, _ := key.(string)
+		out[ks] = val
+	}
+	return out, nil
+}
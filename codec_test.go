@@ -0,0 +1,81 @@
+package btoon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type customDuration struct {
+	Seconds int64
+}
+
+func (d customDuration) MarshalBTOON() ([]byte, error) {
+	return Marshal(d.Seconds)
+}
+
+func (d *customDuration) UnmarshalBTOON(data []byte) error {
+	var seconds int64
+	if err := Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+	d.Seconds = seconds
+	return nil
+}
+
+func TestMarshalerUnmarshalerRoundTrip(t *testing.T) {
+	skipWithoutCore(t)
+	d := customDuration{Seconds: 90}
+
+	encoded, err := Marshal(d)
+	require.NoError(t, err)
+
+	var decoded customDuration
+	err = Unmarshal(encoded, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, d, decoded)
+}
+
+type registeredPoint struct {
+	X, Y int
+}
+
+func TestRegisterType(t *testing.T) {
+	RegisterType("registeredPoint", registeredPoint{})
+
+	entry, ok := codecForType(reflect.TypeOf(registeredPoint{}))
+	require.True(t, ok)
+	assert.Equal(t, "registeredPoint", entry.name)
+
+	_, ok = codecForName("registeredPoint")
+	assert.True(t, ok)
+}
+
+type registeredPointHolder struct {
+	Label string      `btoon:"label"`
+	Point interface{} `btoon:"point"`
+}
+
+// TestFieldEncodeValueHonorsRegistry exercises fieldEncodeValue directly
+// (bypassing Marshal and so the unimplemented C core): a struct field
+// whose dynamic type has a registered codec must come back out as a
+// taggedValue, the same as Encode produces at the top level, instead of
+// falling through to a plain reflection walk that would lose the tag.
+func TestFieldEncodeValueHonorsRegistry(t *testing.T) {
+	RegisterType("registeredPoint", registeredPoint{})
+
+	holder := registeredPointHolder{Label: "origin", Point: registeredPoint{X: 1, Y: 2}}
+	rv := reflect.ValueOf(holder)
+	ti, err := typeInfoFor(rv.Type())
+	require.NoError(t, err)
+
+	fieldValue, err := fieldEncodeValue(rv.FieldByIndex(ti.fields[1].index), ti.fields[1].opts)
+	require.NoError(t, err)
+
+	tagged, ok := fieldValue.(taggedValue)
+	require.True(t, ok, "expected a taggedValue, got %T", fieldValue)
+	assert.Equal(t, "registeredPoint", tagged.name)
+	assert.Equal(t, []interface{}{1, 2}, tagged.value)
+}
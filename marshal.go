@@ -0,0 +1,297 @@
+package btoon
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Marshal encodes v to BTOON format, mirroring encoding/json's Marshal
+// signature. Struct values are walked via reflection, honoring the
+// `btoon` struct tag; all other supported kinds are handled as before.
+func Marshal(v interface{}) ([]byte, error) {
+	return Encode(v)
+}
+
+// Unmarshal decodes BTOON-encoded data into v, which must be a non-nil
+// pointer. Struct targets are populated field-by-field according to the
+// `btoon` struct tag; maps and slices behave as with Decode.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("btoon: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+
+	decoded, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	return assignValue(rv.Elem(), decoded)
+}
+
+// fieldOptions captures the parsed contents of a `btoon:"..."` tag.
+type fieldOptions struct {
+	name      string
+	omitempty bool
+	omitzero  bool
+	optional  bool
+	nilEmpty  bool
+	tail      bool
+	skip      bool
+}
+
+// fieldInfo describes one struct field for the purposes of encoding and
+// decoding, combining its reflect.StructField with parsed tag options.
+type fieldInfo struct {
+	index []int
+	typ   reflect.Type
+	opts  fieldOptions
+}
+
+// typeInfo is the cached, per-type schema derived from walking a struct's
+// fields once. It is keyed by reflect.Type in typeInfoCache so repeated
+// encodes/decodes of the same type skip the reflection walk.
+type typeInfo struct {
+	fields []fieldInfo
+}
+
+var typeInfoCache sync.Map // reflect.Type -> *typeInfo
+
+// parseFieldOptions splits a `btoon` struct tag into its options. The tag
+// format mirrors encoding/json: the first comma-separated entry is the
+// field name (or "-" to skip the field entirely), the rest are flags.
+func parseFieldOptions(field reflect.StructField) fieldOptions {
+	tag := field.Tag.Get("btoon")
+	opts := fieldOptions{name: field.Name}
+	if tag == "" {
+		return opts
+	}
+
+	parts := strings.Split(tag, ",")
+	if parts[0] == "-" && len(parts) == 1 {
+		opts.skip = true
+		return opts
+	}
+	if parts[0] != "" {
+		opts.name = parts[0]
+	}
+
+	for _, part := range parts[1:] {
+		switch {
+		case part == "omitempty":
+			opts.omitempty = true
+		case part == "omitzero":
+			opts.omitzero = true
+		case part == "optional":
+			opts.optional = true
+		case part == "tail":
+			opts.tail = true
+		case part == `nil="empty"` || part == "nil=empty":
+			opts.nilEmpty = true
+		}
+	}
+	return opts
+}
+
+// typeInfoFor returns the cached schema for t, building and storing it on
+// first use. t must be a struct type.
+func typeInfoFor(t reflect.Type) (*typeInfo, error) {
+	if cached, ok := typeInfoCache.Load(t); ok {
+		return cached.(*typeInfo), nil
+	}
+
+	ti := &typeInfo{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue // unexported
+		}
+		opts := parseFieldOptions(sf)
+		if opts.skip {
+			continue
+		}
+		ti.fields = append(ti.fields, fieldInfo{
+			index: sf.Index,
+			typ:   sf.Type,
+			opts:  opts,
+		})
+	}
+
+	for i, f := range ti.fields {
+		if f.opts.tail && i != len(ti.fields)-1 {
+			return nil, fmt.Errorf("btoon: %s.%s: tail option only valid on the last field", t.Name(), f.opts.name)
+		}
+		if f.opts.tail && f.typ.Kind() != reflect.Slice && f.typ.Kind() != reflect.Array {
+			return nil, fmt.Errorf("btoon: %s: tail field must be a slice or array", t.Name())
+		}
+	}
+
+	actual, _ := typeInfoCache.LoadOrStore(t, ti)
+	return actual.(*typeInfo), nil
+}
+
+// structToSlice converts a struct value into the positional []interface{}
+// representation BTOON encodes structs as: one element per non-skipped
+// field, in declaration order, with a trailing "tail" field (if any)
+// spread into the list rather than nested, matching sliceToStruct's
+// "remaining elements" decode semantics. Trailing non-tail fields marked
+// omitempty/omitzero are dropped when they hold their zero value;
+// sliceToStruct tolerates the resulting missing elements for any field
+// with one of those options set (not just "optional"), so the two stay
+// in sync regardless of which option a field uses.
+func structToSlice(rv reflect.Value) ([]interface{}, error) {
+	ti, err := typeInfoFor(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	fields := ti.fields
+	var tailValues []interface{}
+	if n := len(fields); n > 0 && fields[n-1].opts.tail {
+		fv := rv.FieldByIndex(fields[n-1].index)
+		tailValues = make([]interface{}, fv.Len())
+		for j := 0; j < fv.Len(); j++ {
+			tailValues[j] = fv.Index(j).Interface()
+		}
+		fields = fields[:n-1]
+	}
+
+	values := make([]interface{}, len(fields))
+	for i, f := range fields {
+		fv := rv.FieldByIndex(f.index)
+		fieldValue, err := fieldEncodeValue(fv, f.opts)
+		if err != nil {
+			return nil, fmt.Errorf("btoon: field %s: %w", f.opts.name, err)
+		}
+		values[i] = fieldValue
+	}
+
+	// Trim trailing zero-valued omitempty/omitzero fields (the tail, if
+	// any, is always kept in full and appended after trimming). No
+	// trimming happens when the tail has elements: they would otherwise
+	// shift into the trimmed fields' positions on decode.
+	end := len(values)
+	for end > 0 && len(tailValues) == 0 {
+		f := fields[end-1]
+		if !f.opts.omitempty && !f.opts.omitzero {
+			break
+		}
+		if values[end-1] != nil && !reflect.ValueOf(values[end-1]).IsZero() {
+			break
+		}
+		end--
+	}
+	values = values[:end]
+	if tailValues != nil {
+		values = append(values, tailValues...)
+	}
+	return values, nil
+}
+
+// fieldEncodeValue applies nilEmpty handling to a pointer field, gives a
+// Marshaler field (e.g. RawMessage) or a field whose dynamic type has a
+// registered codec the chance to encode itself, and otherwise falls
+// through to the field's underlying value. Routing through
+// encodeViaRegistryOrMarshaler here (the same check Encode applies at
+// the top level) is what lets a registered type nested inside a struct
+// field come back out as its concrete type on decode instead of a plain
+// map: without it, fv.Interface() below would hand the field to
+// structToSlice's plain reflection walk, which has no way to tag it for
+// decodeViaRegistry to find.
+func fieldEncodeValue(fv reflect.Value, opts fieldOptions) (interface{}, error) {
+	if fv.Kind() == reflect.Ptr && fv.IsNil() {
+		if opts.nilEmpty {
+			return reflect.Zero(fv.Type().Elem()).Interface(), nil
+		}
+		return nil, nil
+	}
+
+	if fv.CanInterface() {
+		if generic, ok, err := encodeViaRegistryOrMarshaler(fv.Interface()); ok {
+			return generic, err
+		}
+	}
+
+	if fv.Kind() == reflect.Ptr {
+		return fv.Elem().Interface(), nil
+	}
+	return fv.Interface(), nil
+}
+
+// sliceToStruct populates rv (a struct value) from the positional decoded
+// elements in values, the inverse of structToSlice.
+func sliceToStruct(rv reflect.Value, values []interface{}) error {
+	ti, err := typeInfoFor(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for i, f := range ti.fields {
+		if i >= len(values) {
+			if f.opts.optional || f.opts.tail || f.opts.omitempty || f.opts.omitzero {
+				continue // left as zero value
+			}
+			return fmt.Errorf("btoon: missing required field %s", f.opts.name)
+		}
+
+		fv := rv.FieldByIndex(f.index)
+		if f.opts.tail {
+			remaining := values[i:]
+			tail := reflect.MakeSlice(f.typ, len(remaining), len(remaining))
+			for j, elem := range remaining {
+				if err := assignValue(tail.Index(j), elem); err != nil {
+					return err
+				}
+			}
+			fv.Set(tail)
+			break
+		}
+		if err := assignValue(fv, values[i]); err != nil {
+			return fmt.Errorf("btoon: field %s: %w", f.opts.name, err)
+		}
+	}
+	return nil
+}
+
+// assignValue stores decoded (a generic Decode result) into dst, handling
+// struct targets via sliceToStruct and otherwise relying on reflection to
+// cover maps, slices, and primitives.
+func assignValue(dst reflect.Value, decoded interface{}) error {
+	if decoded == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		dst.Set(reflect.New(dst.Type().Elem()))
+		return assignValue(dst.Elem(), decoded)
+	}
+
+	if u, ok := dst.Addr().Interface().(Unmarshaler); ok {
+		raw, err := Marshal(decoded)
+		if err != nil {
+			return err
+		}
+		return u.UnmarshalBTOON(raw)
+	}
+
+	if dst.Kind() == reflect.Struct {
+		values, ok := decoded.([]interface{})
+		if !ok {
+			return fmt.Errorf("btoon: expected positional struct data, got %T", decoded)
+		}
+		return sliceToStruct(dst, values)
+	}
+
+	dv := reflect.ValueOf(decoded)
+	if dv.Type().AssignableTo(dst.Type()) {
+		dst.Set(dv)
+		return nil
+	}
+	if dv.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(dv.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("btoon: cannot assign %s into %s", dv.Type(), dst.Type())
+}
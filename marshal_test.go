@@ -0,0 +1,65 @@
+package btoon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type marshalPerson struct {
+	Name     string   `btoon:"name"`
+	Age      int      `btoon:"age,omitempty"`
+	Nickname string   `btoon:"nickname,optional"`
+	Tags     []string `btoon:"tags,tail"`
+}
+
+func TestMarshalStruct(t *testing.T) {
+	skipWithoutCore(t)
+	p := marshalPerson{Name: "Ada", Age: 30, Tags: []string{"admin", "user"}}
+
+	encoded, err := Marshal(p)
+	require.NoError(t, err)
+	assert.NotEmpty(t, encoded)
+
+	var decoded marshalPerson
+	err = Unmarshal(encoded, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, p, decoded)
+}
+
+func TestUnmarshalRequiresPointer(t *testing.T) {
+	var p marshalPerson
+	err := Unmarshal([]byte{0x00}, p)
+	assert.Error(t, err)
+}
+
+// TestStructToSliceOmitemptyTrimRoundTrips exercises structToSlice and
+// sliceToStruct directly, bypassing Marshal/Unmarshal (and so the
+// unimplemented C core): a trailing omitempty field with no "optional"
+// tag must be trimmable on encode and tolerated as missing on decode,
+// not just fields also marked optional.
+func TestStructToSliceOmitemptyTrimRoundTrips(t *testing.T) {
+	type record struct {
+		Name  string `btoon:"name"`
+		Count int    `btoon:"count,omitempty"`
+	}
+
+	values, err := structToSlice(reflect.ValueOf(record{Name: "Ada"}))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"Ada"}, values)
+
+	var decoded record
+	require.NoError(t, sliceToStruct(reflect.ValueOf(&decoded).Elem(), values))
+	assert.Equal(t, record{Name: "Ada"}, decoded)
+}
+
+func TestFieldOptionsTagParsing(t *testing.T) {
+	ti, err := typeInfoFor(reflect.TypeOf(marshalPerson{}))
+	require.NoError(t, err)
+	require.Len(t, ti.fields, 4)
+	assert.Equal(t, "nickname", ti.fields[2].opts.name)
+	assert.True(t, ti.fields[2].opts.optional)
+	assert.True(t, ti.fields[3].opts.tail)
+}
@@ -0,0 +1,28 @@
+package btoon
+
+// RawMessage holds an already-encoded BTOON value verbatim. A struct
+// field of this type is spliced in as-is during encode. During decode
+// the field's sub-tree is decoded along with the rest of the document
+// and then encoded again with Marshal into the RawMessage, so the bytes
+// it holds are a re-encoding of that value, not the original bytes, and
+// the sub-tree is not skipped.
+type RawMessage []byte
+
+// MarshalBTOON returns m unchanged, so Encode splices it into the output
+// in place rather than re-encoding it.
+func (m RawMessage) MarshalBTOON() ([]byte, error) {
+	if m == nil {
+		return []byte{}, nil
+	}
+	return m, nil
+}
+
+// UnmarshalBTOON stores a copy of data in m without interpreting it.
+func (m *RawMessage) UnmarshalBTOON(data []byte) error {
+	if data == nil {
+		*m = nil
+		return nil
+	}
+	*m = append((*m)[0:0], data...)
+	return nil
+}
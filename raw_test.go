@@ -0,0 +1,35 @@
+package btoon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rawEnvelope struct {
+	ID      int        `btoon:"id"`
+	Payload RawMessage `btoon:"payload"`
+}
+
+func TestRawMessageLeftEncoded(t *testing.T) {
+	skipWithoutCore(t)
+	inner, err := Marshal(map[string]interface{}{"nested": true})
+	require.NoError(t, err)
+
+	env := rawEnvelope{ID: 1, Payload: RawMessage(inner)}
+	encoded, err := Marshal(env)
+	require.NoError(t, err)
+
+	var decoded rawEnvelope
+	err = Unmarshal(encoded, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, env.Payload, decoded.Payload)
+}
+
+func TestRawMessageNil(t *testing.T) {
+	var m RawMessage
+	out, err := m.MarshalBTOON()
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
@@ -0,0 +1,261 @@
+package btoon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// streamMagic prefixes every frame so a reader can resynchronize after a
+// corrupt record instead of misinterpreting arbitrary bytes as a length.
+var streamMagic = [4]byte{'B', 'T', 'N', '1'}
+
+// ErrCorruptFrame is returned by StreamDecoder.Decode when a frame's
+// CRC32 trailer doesn't match its payload.
+var ErrCorruptFrame = errors.New("btoon: corrupt stream frame")
+
+// frameFlagCRC32 marks, in a frame's flags byte, that a CRC32 trailer
+// follows the payload.
+const frameFlagCRC32 = 1 << 0
+
+// frameFlagCompressed marks, in a frame's flags byte, that the payload
+// was written with opt.Compress and is decompressed before decoding. The
+// frame's algorithm byte is read but not passed to decompression.
+const frameFlagCompressed = 1 << 1
+
+// StreamEncoder writes a sequence of values to w as self-delimited
+// framed records: magic + compression algorithm byte + flags byte +
+// varint payload length + payload + optional CRC32 trailer. Unlike Encode/Decode, it
+// never buffers more than one record at a time, so it's suited to
+// emitting multi-gigabyte outputs.
+type StreamEncoder struct {
+	w    io.Writer
+	opts EncodeOptions
+}
+
+// NewStreamEncoder creates a streaming encoder that writes framed
+// records to w. opts (the same options accepted by Encode) govern every
+// record unless overridden per-call via Encode's own opts parameter.
+func NewStreamEncoder(w io.Writer, opts ...EncodeOptions) *StreamEncoder {
+	s := &StreamEncoder{w: w}
+	if len(opts) > 0 {
+		s.opts = opts[0]
+	}
+	return s
+}
+
+// Encode writes v to the stream as one framed record. Passing opts
+// overrides the encoder's default options for this record only, so a
+// single stream can mix compressed and uncompressed (or CRC-checked and
+// unchecked) records.
+func (s *StreamEncoder) Encode(v interface{}, opts ...EncodeOptions) error {
+	opt := s.opts
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	payload, err := Encode(v, opt)
+	if err != nil {
+		return err
+	}
+
+	var flags byte
+	if opt.CRC32 {
+		flags |= frameFlagCRC32
+	}
+	if opt.Compress {
+		flags |= frameFlagCompressed
+	}
+
+	var header [4 + 1 + 1 + binary.MaxVarintLen64]byte
+	copy(header[:4], streamMagic[:])
+	header[4] = byte(opt.Algorithm)
+	header[5] = flags
+	n := binary.PutUvarint(header[6:], uint64(len(payload)))
+	if _, err := s.w.Write(header[:6+n]); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(payload); err != nil {
+		return err
+	}
+
+	if opt.CRC32 {
+		var trailer [4]byte
+		binary.BigEndian.PutUint32(trailer[:], crc32.ChecksumIEEE(payload))
+		if _, err := s.w.Write(trailer[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases resources held by the encoder. If the underlying
+// writer implements io.Closer, it is closed too.
+func (s *StreamEncoder) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// StreamDecoder reads a sequence of framed records written by a
+// StreamEncoder, one per Decode call.
+type StreamDecoder struct {
+	r    *bufio.Reader
+	src  io.Reader
+	done bool
+}
+
+// NewStreamDecoder creates a streaming decoder reading framed records
+// from r.
+func NewStreamDecoder(r io.Reader) *StreamDecoder {
+	return &StreamDecoder{r: bufio.NewReader(r), src: r}
+}
+
+// More reports whether another record is available without consuming
+// it, in the style of json.Decoder.More.
+func (s *StreamDecoder) More() bool {
+	if s.done {
+		return false
+	}
+	_, err := s.r.Peek(1)
+	return err == nil
+}
+
+// Buffered returns a reader over the bytes already buffered by the
+// decoder but not yet handed back by Decode.
+func (s *StreamDecoder) Buffered() io.Reader {
+	buffered, _ := s.r.Peek(s.r.Buffered())
+	return bytes.NewReader(buffered)
+}
+
+// Decode reads and decodes the next record from the stream, returning
+// io.EOF once the stream is exhausted.
+func (s *StreamDecoder) Decode() (interface{}, error) {
+	payload, compressed, crcOK, err := s.readFrame()
+	if err != nil {
+		return nil, err
+	}
+	if !crcOK {
+		return nil, ErrCorruptFrame
+	}
+	return Decode(payload, DecodeOptions{Decompress: compressed})
+}
+
+// readFrame reads one frame's header and payload, reporting whether the
+// frame's Compress flag was set and, if a CRC32 trailer is present,
+// whether it matches the payload.
+func (s *StreamDecoder) readFrame() (payload []byte, compressed, crcOK bool, err error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(s.r, magic[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		s.done = true
+		return nil, false, false, err
+	}
+	if magic != streamMagic {
+		return nil, false, false, ErrCorruptFrame
+	}
+
+	algo, err := s.r.ReadByte()
+	if err != nil {
+		return nil, false, false, err
+	}
+	_ = CompressionAlgorithm(algo)
+
+	flags, err := s.r.ReadByte()
+	if err != nil {
+		return nil, false, false, err
+	}
+	compressed = flags&frameFlagCompressed != 0
+
+	length, err := binary.ReadUvarint(s.r)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(s.r, payload); err != nil {
+		return nil, false, false, err
+	}
+
+	if flags&frameFlagCRC32 != 0 {
+		var trailer [4]byte
+		if _, err := io.ReadFull(s.r, trailer[:]); err != nil {
+			return nil, false, false, err
+		}
+		want := binary.BigEndian.Uint32(trailer[:])
+		return payload, compressed, crc32.ChecksumIEEE(payload) == want, nil
+	}
+	return payload, compressed, true, nil
+}
+
+// Close releases resources held by the decoder. If the underlying
+// reader implements io.Closer, it is closed too.
+func (s *StreamDecoder) Close() error {
+	if c, ok := s.src.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// TokenType identifies the kind of event produced by StreamDecoder.Token.
+type TokenType int
+
+const (
+	StartMap TokenType = iota
+	EndMap
+	StartArray
+	EndArray
+	Key
+	Value
+)
+
+// Token is one event in the low-level, allocation-light walk over a
+// decoded record produced by StreamDecoder.Token.
+type Token struct {
+	Type  TokenType
+	Value interface{} // populated for Key and Value tokens
+}
+
+// Token decodes the next record and returns its constituent events in
+// document order. Unlike Any's Get/ForEach, Token cannot walk the wire
+// bytes directly: a record's payload is in the BTOON core's format, not
+// Any's layout, and is opaque until decodeData (the BTOON core) turns it
+// into a Go value. Token therefore decodes the full record up front and
+// walks the resulting Go value, rather than emitting events as it
+// consumes bytes off the wire. Map keys are emitted in Go map iteration
+// order, which is not defined.
+func (s *StreamDecoder) Token() ([]Token, error) {
+	v, err := s.Decode()
+	if err != nil {
+		return nil, err
+	}
+	var tokens []Token
+	appendTokens(v, &tokens)
+	return tokens, nil
+}
+
+func appendTokens(v interface{}, tokens *[]Token) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		*tokens = append(*tokens, Token{Type: StartMap})
+		for k, child := range val {
+			*tokens = append(*tokens, Token{Type: Key, Value: k})
+			appendTokens(child, tokens)
+		}
+		*tokens = append(*tokens, Token{Type: EndMap})
+	case []interface{}:
+		*tokens = append(*tokens, Token{Type: StartArray})
+		for _, child := range val {
+			appendTokens(child, tokens)
+		}
+		*tokens = append(*tokens, Token{Type: EndArray})
+	default:
+		*tokens = append(*tokens, Token{Type: Value, Value: val})
+	}
+}
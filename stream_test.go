@@ -0,0 +1,60 @@
+package btoon
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamEncoderMixedOptionsPerRecord(t *testing.T) {
+	skipWithoutCore(t)
+	var buf bytes.Buffer
+	encoder := NewStreamEncoder(&buf)
+
+	require.NoError(t, encoder.Encode("plain"))
+	require.NoError(t, encoder.Encode("checked", EncodeOptions{CRC32: true}))
+
+	decoder := NewStreamDecoder(&buf)
+
+	first, err := decoder.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, "plain", first)
+
+	second, err := decoder.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, "checked", second)
+
+	_, err = decoder.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestStreamDecoderDetectsCorruption(t *testing.T) {
+	skipWithoutCore(t)
+	var buf bytes.Buffer
+	encoder := NewStreamEncoder(&buf, EncodeOptions{CRC32: true})
+	require.NoError(t, encoder.Encode("value"))
+
+	corrupted := buf.Bytes()
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	decoder := NewStreamDecoder(bytes.NewReader(corrupted))
+	_, err := decoder.Decode()
+	assert.Equal(t, ErrCorruptFrame, err)
+}
+
+func TestStreamDecoderToken(t *testing.T) {
+	skipWithoutCore(t)
+	var buf bytes.Buffer
+	encoder := NewStreamEncoder(&buf)
+	require.NoError(t, encoder.Encode(map[string]interface{}{"a": 1}))
+
+	decoder := NewStreamDecoder(&buf)
+	tokens, err := decoder.Token()
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens)
+	assert.Equal(t, StartMap, tokens[0].Type)
+	assert.Equal(t, EndMap, tokens[len(tokens)-1].Type)
+}
@@ -0,0 +1,268 @@
+package btoon
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// tabularMarkerKey identifies the wire-level wrapper map produced by
+// encodeTabular, so the decode path can tell a columnar block apart from
+// an ordinary map and transparently reconstruct the original rows.
+const tabularMarkerKey = "__btoon_tabular__"
+
+// tabularMinRows is the default minimum slice length before AutoTabular
+// considers switching a []map[string]interface{} to columnar encoding.
+const tabularMinRows = 8
+
+// tabularSchemaThreshold is the fraction of rows that must share a
+// schema for AutoTabular to trigger; rows that diverge are kept in a
+// sparse overrides section instead of forcing row-oriented encoding.
+const tabularSchemaThreshold = 0.9
+
+// Stats reports what AutoTabular did for one Encode call. It is only
+// populated when EncodeOptions.Stats points at one.
+type Stats struct {
+	RowsCompacted  int // rows folded into the columnar block
+	ColumnsEmitted int // distinct columns written to the header block
+	Overrides      int // rows stored individually because they diverged
+}
+
+// tabularColumn is one column of a columnar block: the shared field name
+// and its per-row values, in row order.
+type tabularColumn struct {
+	name   string
+	values []interface{}
+}
+
+// tabularBlock is the columnar representation of a homogeneous
+// []map[string]interface{}, built by tryTabularEncode.
+type tabularBlock struct {
+	columns   []tabularColumn
+	overrides map[int]map[string]interface{}
+	stats     Stats
+}
+
+// tryTabularEncode attempts to fold rows into a columnar block. It
+// returns ok=false when rows isn't eligible (too few rows, or too little
+// schema agreement), in which case the caller should fall back to
+// ordinary row-oriented encoding.
+func tryTabularEncode(rows []map[string]interface{}) (*tabularBlock, bool) {
+	if len(rows) < tabularMinRows {
+		return nil, false
+	}
+
+	schemaKey, schemaCounts := dominantSchema(rows)
+	dominantCount := schemaCounts[joinSchema(schemaKey)]
+	if float64(dominantCount)/float64(len(rows)) < tabularSchemaThreshold {
+		return nil, false
+	}
+
+	names := schemaKey
+	block := &tabularBlock{
+		overrides: map[int]map[string]interface{}{},
+	}
+	columns := make([]tabularColumn, len(names))
+	for i, name := range names {
+		columns[i] = tabularColumn{name: name, values: make([]interface{}, 0, len(rows))}
+	}
+
+	for i, row := range rows {
+		if !matchesSchema(row, names) {
+			block.overrides[i] = row
+			block.stats.Overrides++
+			continue
+		}
+		for ci, name := range names {
+			columns[ci].values = append(columns[ci].values, row[name])
+		}
+		block.stats.RowsCompacted++
+	}
+
+	block.columns = columns
+	block.stats.ColumnsEmitted = len(columns)
+	return block, true
+}
+
+// rowSchema returns a row's keys, sorted for stable comparison.
+func rowSchema(row map[string]interface{}) []string {
+	keys := make([]string, 0, len(row))
+	for k := range row {
+		keys = append(keys, k)
+	}
+	sortStrings(keys)
+	return keys
+}
+
+// dominantSchema finds the most common key set among rows, returning it
+// along with a count of how many rows share each distinct key set.
+func dominantSchema(rows []map[string]interface{}) ([]string, map[string]int) {
+	counts := map[string]int{}
+	schemas := map[string][]string{}
+	for _, row := range rows {
+		schema := rowSchema(row)
+		key := joinSchema(schema)
+		counts[key]++
+		schemas[key] = schema
+	}
+
+	var best string
+	for key, n := range counts {
+		if n > counts[best] {
+			best = key
+		}
+	}
+	return schemas[best], counts
+}
+
+func matchesSchema(row map[string]interface{}, names []string) bool {
+	if len(row) != len(names) {
+		return false
+	}
+	for _, name := range names {
+		if _, ok := row[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func joinSchema(keys []string) string {
+	key := ""
+	for i, k := range keys {
+		if i > 0 {
+			key += "\x00"
+		}
+		key += k
+	}
+	return key
+}
+
+func sortStrings(s []string) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j] < s[j-1]; j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+}
+
+// rowsFromInterfaceSlice extracts []map[string]interface{} from v when v
+// is either that type directly or a []interface{}/slice-of-struct whose
+// elements all normalize to maps. It returns ok=false otherwise.
+func rowsFromInterfaceSlice(v interface{}) ([]map[string]interface{}, bool) {
+	if rows, ok := v.([]map[string]interface{}); ok {
+		return rows, true
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Len() == 0 {
+		return nil, false
+	}
+
+	elemKind := rv.Type().Elem().Kind()
+	if elemKind != reflect.Struct && elemKind != reflect.Interface {
+		return nil, false
+	}
+
+	rows := make([]map[string]interface{}, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		for elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			return nil, false
+		}
+		slice, err := structToSlice(elem)
+		if err != nil {
+			return nil, false
+		}
+		ti, err := typeInfoFor(elem.Type())
+		if err != nil || len(ti.fields) != len(slice) {
+			return nil, false
+		}
+		row := make(map[string]interface{}, len(slice))
+		for i, f := range ti.fields {
+			row[f.opts.name] = slice[i]
+		}
+		rows[i] = row
+	}
+	return rows, true
+}
+
+// encodeTabular converts a tabularBlock into the generic map
+// representation written to the wire, keyed so decodeTabular can
+// recognize and reverse it.
+func encodeTabular(block *tabularBlock, total int) map[string]interface{} {
+	columns := make([]interface{}, len(block.columns))
+	for i, col := range block.columns {
+		columns[i] = map[string]interface{}{
+			"name":   col.name,
+			"values": col.values,
+		}
+	}
+	overrides := make(map[string]interface{}, len(block.overrides))
+	for rowIndex, row := range block.overrides {
+		overrides[fmt.Sprintf("%d", rowIndex)] = row
+	}
+	return map[string]interface{}{
+		tabularMarkerKey: true,
+		"total":          total,
+		"columns":        columns,
+		"overrides":      overrides,
+	}
+}
+
+// decodeTabular reverses encodeTabular, reconstructing the original
+// row-oriented []map[string]interface{}. ok is false when v isn't a
+// tabular wrapper map, in which case the caller should treat v as an
+// ordinary decoded value.
+func decodeTabular(v interface{}) ([]map[string]interface{}, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok || m[tabularMarkerKey] != true {
+		return nil, false
+	}
+
+	total, _ := m["total"].(int)
+	columnsRaw, _ := m["columns"].([]interface{})
+	overridesRaw, _ := m["overrides"].(map[string]interface{})
+
+	columnValues := make(map[string][]interface{}, len(columnsRaw))
+	columnOrder := make([]string, 0, len(columnsRaw))
+	for _, c := range columnsRaw {
+		col, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := col["name"].(string)
+		values, _ := col["values"].([]interface{})
+		columnValues[name] = values
+		columnOrder = append(columnOrder, name)
+	}
+
+	overrides := make(map[int]map[string]interface{}, len(overridesRaw))
+	for idxStr, row := range overridesRaw {
+		var idx int
+		fmt.Sscanf(idxStr, "%d", &idx)
+		if rowMap, ok := row.(map[string]interface{}); ok {
+			overrides[idx] = rowMap
+		}
+	}
+
+	rows := make([]map[string]interface{}, total)
+	columnRow := 0
+	for i := 0; i < total; i++ {
+		if row, ok := overrides[i]; ok {
+			rows[i] = row
+			continue
+		}
+		row := make(map[string]interface{}, len(columnOrder))
+		for _, name := range columnOrder {
+			if columnRow < len(columnValues[name]) {
+				row[name] = columnValues[name][columnRow]
+			}
+		}
+		rows[i] = row
+		columnRow++
+	}
+	return rows, true
+}
@@ -0,0 +1,75 @@
+package btoon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func logRows(n int) []map[string]interface{} {
+	rows := make([]map[string]interface{}, n)
+	for i := range rows {
+		rows[i] = map[string]interface{}{
+			"id":    i,
+			"level": "info",
+			"msg":   "request handled",
+		}
+	}
+	return rows
+}
+
+func TestAutoTabularRoundTrip(t *testing.T) {
+	skipWithoutCore(t)
+	rows := logRows(20)
+
+	var stats Stats
+	encoded, err := Encode(rows, EncodeOptions{AutoTabular: true, Stats: &stats})
+	require.NoError(t, err)
+	assert.Equal(t, 20, stats.RowsCompacted)
+	assert.Equal(t, 3, stats.ColumnsEmitted)
+
+	decoded, err := Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, rows, decoded)
+}
+
+func TestAutoTabularBelowMinRows(t *testing.T) {
+	rows := logRows(3)
+	_, ok := tryTabularEncode(rows)
+	assert.False(t, ok)
+}
+
+func TestAutoTabularOverrides(t *testing.T) {
+	rows := logRows(10)
+	rows[5] = map[string]interface{}{"id": 5, "level": "error", "msg": "failed", "trace": "xyz"}
+
+	block, ok := tryTabularEncode(rows)
+	require.True(t, ok)
+	assert.Equal(t, 1, block.stats.Overrides)
+	assert.Equal(t, 9, block.stats.RowsCompacted)
+}
+
+func BenchmarkEncodeAutoTabular(b *testing.B) {
+	rows := logRows(10000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := Encode(rows, EncodeOptions{AutoTabular: true})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeRowOriented(b *testing.B) {
+	rows := logRows(10000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := Encode(rows, EncodeOptions{AutoTabular: false})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}